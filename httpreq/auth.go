@@ -0,0 +1,359 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"crypto/md5"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator applies credentials to an outgoing request. Implementations
+// are supplied to a Request via Auth and are invoked once per dispatch
+// attempt, right before headers are finalized.
+type Authenticator interface {
+	Apply(req *http.Request) error
+}
+
+// clientAwareAuthenticator is an optional interface for Authenticators that
+// need to make their own HTTP calls (e.g. fetching an OAuth2 token). Request
+// calls useClient before the first Apply so these calls go through the same
+// client/transport as the request itself, honoring ProxyURL/HTTPClient
+// instead of reaching out on a bare client.
+type clientAwareAuthenticator interface {
+	useClient(httpcli *http.Client)
+}
+
+// Auth sets the Authenticator used to sign/authenticate the request. Passing
+// a nil Authenticator clears any previously set one.
+func (r *Request) Auth(auth Authenticator) *Request {
+	r.auth = auth
+	return r
+}
+
+// basicAuthenticator implements BasicAuth on top of the Authenticator
+// interface, preserving the original behaviour of only adding the header
+// when at least one of username/password is set.
+type basicAuthenticator struct {
+	username string
+	password string
+}
+
+// Apply implements Authenticator
+func (a *basicAuthenticator) Apply(req *http.Request) error {
+	if a.username == "" && a.password == "" {
+		return nil
+	}
+	req.Header.Add("Authorization", "Basic "+basicAuth(a.username, a.password))
+	return nil
+}
+
+// bearerTokenAuthenticator implements BearerTokenAuth
+type bearerTokenAuthenticator struct {
+	token string
+}
+
+// Apply implements Authenticator
+func (a *bearerTokenAuthenticator) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// BearerTokenAuth returns an Authenticator that sets the
+// "Authorization: Bearer <token>" header
+func BearerTokenAuth(token string) Authenticator {
+	return &bearerTokenAuthenticator{token: token}
+}
+
+// apiKeyAuthenticator implements APIKeyAuth
+type apiKeyAuthenticator struct {
+	header string
+	value  string
+}
+
+// Apply implements Authenticator
+func (a *apiKeyAuthenticator) Apply(req *http.Request) error {
+	req.Header.Set(a.header, a.value)
+	return nil
+}
+
+// APIKeyAuth returns an Authenticator that sets an arbitrary header (commonly
+// "X-API-Key" or similar) to value
+func APIKeyAuth(header, value string) Authenticator {
+	return &apiKeyAuthenticator{header: header, value: value}
+}
+
+// digestChallenge holds the parsed parameters of a WWW-Authenticate: Digest
+// challenge, as described in RFC 7616 section 3.3
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	qop       string
+	algorithm string
+}
+
+// digestAuthenticator implements DigestAuth. The first request it signs is a
+// no-op probe; once Dispatch feeds it the resulting 401 challenge via
+// handleChallenge, subsequent Apply calls compute a real "Digest ..."
+// Authorization header per RFC 7616.
+type digestAuthenticator struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        uint32
+}
+
+// DigestAuth returns an Authenticator implementing HTTP Digest access
+// authentication (RFC 7616) with MD5 or SHA-256 and qop=auth. Because the
+// digest response depends on a server-issued nonce, Dispatch performs a
+// pre-flight challenge request the first time it is used and then re-issues
+// the original request with the computed Authorization header.
+func DigestAuth(username, password string) Authenticator {
+	return &digestAuthenticator{username: username, password: password}
+}
+
+// Apply implements Authenticator
+func (a *digestAuthenticator) Apply(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.challenge == nil {
+		return nil
+	}
+
+	cnonce, err := randomHex(16)
+	if err != nil {
+		return fmt.Errorf("failed to generate digest cnonce: %w", err)
+	}
+	a.nc++
+	nc := fmt.Sprintf("%08x", a.nc)
+
+	newHash := digestHasher(a.challenge.algorithm)
+	ha1 := digestHash(newHash, a.username+":"+a.challenge.realm+":"+a.password)
+	ha2 := digestHash(newHash, req.Method+":"+req.URL.RequestURI())
+	response := digestHash(newHash, strings.Join([]string{ha1, a.challenge.nonce, nc, cnonce, "auth", ha2}, ":"))
+
+	header := fmt.Sprintf(
+		`Digest username="%s", realm="%s", nonce="%s", uri="%s", qop=auth, nc=%s, cnonce="%s", response="%s"`,
+		a.username, a.challenge.realm, a.challenge.nonce, req.URL.RequestURI(), nc, cnonce, response,
+	)
+	if a.challenge.opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, a.challenge.opaque)
+	}
+	if a.challenge.algorithm != "" {
+		header += fmt.Sprintf(`, algorithm=%s`, a.challenge.algorithm)
+	}
+
+	req.Header.Set("Authorization", header)
+	return nil
+}
+
+// needsChallenge reports whether this authenticator hasn't yet received a
+// digest challenge from the server
+func (a *digestAuthenticator) needsChallenge() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.challenge == nil
+}
+
+// handleChallenge parses a WWW-Authenticate: Digest response header and
+// caches it for subsequent Apply calls. It reports whether a challenge was
+// found and stored.
+func (a *digestAuthenticator) handleChallenge(res *http.Response) bool {
+	header := res.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(header, "Digest ") {
+		return false
+	}
+
+	challenge := &digestChallenge{algorithm: "MD5"}
+	for _, field := range splitDigestParams(strings.TrimPrefix(header, "Digest ")) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch key {
+		case "realm":
+			challenge.realm = value
+		case "nonce":
+			challenge.nonce = value
+		case "opaque":
+			challenge.opaque = value
+		case "qop":
+			challenge.qop = value
+		case "algorithm":
+			challenge.algorithm = value
+		}
+	}
+	if challenge.nonce == "" {
+		return false
+	}
+
+	a.mu.Lock()
+	a.challenge = challenge
+	a.nc = 0
+	a.mu.Unlock()
+	return true
+}
+
+// splitDigestParams splits a Digest challenge's comma-separated field list,
+// ignoring commas that appear inside quoted values
+func splitDigestParams(fields string) []string {
+	var parts []string
+	var quoted bool
+	start := 0
+	for i, c := range fields {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, fields[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, fields[start:])
+	return parts
+}
+
+// digestHasher returns the hash.Hash constructor for a digest algorithm name
+func digestHasher(algorithm string) func() hash.Hash {
+	if strings.EqualFold(algorithm, "SHA-256") {
+		return sha256.New
+	}
+	return md5.New
+}
+
+// digestHash hashes data and returns it hex-encoded
+func digestHash(newHash func() hash.Hash, data string) string {
+	h := newHash()
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// randomHex returns n random bytes hex-encoded, used for the digest cnonce
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := cryptorand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// oauth2ClientCredentialsAuthenticator implements OAuth2ClientCredentials,
+// fetching and caching an access token until it expires
+type oauth2ClientCredentialsAuthenticator struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu      sync.Mutex
+	httpcli *http.Client
+	token   string
+	expiry  time.Time
+}
+
+// OAuth2ClientCredentials returns an Authenticator implementing the OAuth2
+// client credentials grant (RFC 6749 section 4.4). The access token is
+// fetched from tokenURL on first use and cached until it expires.
+func OAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) Authenticator {
+	return &oauth2ClientCredentialsAuthenticator{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+// useClient implements clientAwareAuthenticator, letting the token fetch
+// reuse the request's configured client/transport (e.g. ProxyURL) instead
+// of a bare http.Client
+func (a *oauth2ClientCredentialsAuthenticator) useClient(httpcli *http.Client) {
+	a.mu.Lock()
+	a.httpcli = httpcli
+	a.mu.Unlock()
+}
+
+// Apply implements Authenticator
+func (a *oauth2ClientCredentialsAuthenticator) Apply(req *http.Request) error {
+	token, err := a.tokenFor()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+var errOAuth2TokenMissing = errors.New("oauth2 token response did not include an access_token")
+
+// tokenFor returns a cached access token, fetching a fresh one from tokenURL
+// if there isn't one yet or the cached one has expired
+func (a *oauth2ClientCredentialsAuthenticator) tokenFor() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "client_credentials")
+	values.Set("client_id", a.clientID)
+	values.Set("client_secret", a.clientSecret)
+	if len(a.scopes) > 0 {
+		values.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	httpcli := a.httpcli
+	if httpcli == nil {
+		httpcli = &http.Client{Timeout: defaultTimeout}
+	}
+	res, err := httpcli.PostForm(a.tokenURL, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch oauth2 token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth2 token endpoint returned status %d", res.StatusCode)
+	}
+
+	var tokenRes struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tokenRes); err != nil {
+		return "", fmt.Errorf("failed to decode oauth2 token response: %w", err)
+	}
+	if tokenRes.AccessToken == "" {
+		return "", errOAuth2TokenMissing
+	}
+
+	a.token = tokenRes.AccessToken
+	a.expiry = time.Now().Add(defaultTimeout)
+	if tokenRes.ExpiresIn != "" {
+		if secs, err := strconv.ParseInt(tokenRes.ExpiresIn.String(), 10, 64); err == nil && secs > 0 {
+			a.expiry = time.Now().Add(time.Duration(secs) * time.Second)
+		}
+	}
+
+	return a.token, nil
+}
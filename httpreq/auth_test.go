@@ -0,0 +1,208 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRequestBearerTokenAuth(t *testing.T) {
+	var gotAuth string
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Auth(BearerTokenAuth("sekret")).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if want := "Bearer sekret"; gotAuth != want {
+		t.Errorf("expected Authorization %q, got %q", want, gotAuth)
+	}
+}
+
+func TestRequestAPIKeyAuth(t *testing.T) {
+	var gotKey string
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-API-Key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Auth(APIKeyAuth("X-API-Key", "abc123")).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if want := "abc123"; gotKey != want {
+		t.Errorf("expected X-API-Key %q, got %q", want, gotKey)
+	}
+}
+
+func TestRequestDigestAuth(t *testing.T) {
+	const username, password, realm, nonce = "alice", "wonderland", "testrealm", "abc123nonce"
+	var authorized bool
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			authorized = true
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", nonce="`+nonce+`", opaque="xyz"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Auth(DigestAuth(username, password)).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if !authorized {
+		t.Error("expected the request to eventually carry a Digest Authorization header")
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestRequestDigestAuthWithFormFileUpload(t *testing.T) {
+	const username, password, realm, nonce = "alice", "wonderland", "testrealm", "abc123nonce"
+	var gotFileContents string
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", nonce="`+nonce+`", opaque="xyz"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		file, _, err := r.FormFile("upload")
+		if err != nil {
+			t.Fatalf("failed to read form file: %v", err)
+		}
+		defer file.Close()
+
+		contents, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatalf("failed to read file contents: %v", err)
+		}
+		gotFileContents = string(contents)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodPost, serv.URL).
+		Auth(DigestAuth(username, password)).
+		FormFile("upload", "greeting.txt", strings.NewReader("hello multipart")).
+		Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if gotFileContents != "hello multipart" {
+		t.Errorf("expected the authenticated upload to still carry the file contents, got %q", gotFileContents)
+	}
+}
+
+func TestRequestOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests int
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"tok-abc123","expires_in":3600}`))
+		default:
+			if got, want := r.Header.Get("Authorization"), "Bearer tok-abc123"; got != want {
+				t.Errorf("expected Authorization %q, got %q", want, got)
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer serv.Close()
+
+	auth := OAuth2ClientCredentials(serv.URL+"/token", "client-id", "client-secret", "read", "write")
+
+	for i := 0; i < 2; i++ {
+		res, err := New(http.MethodGet, serv.URL).Auth(auth).Dispatch()
+		if err != nil {
+			t.Fatalf("didn't expect error: %v", err)
+		}
+		res.Body.Close()
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("expected the token to be fetched once and cached, got %d fetches", tokenRequests)
+	}
+}
+
+func TestRequestOAuth2ClientCredentialsUsesProxy(t *testing.T) {
+	var tokenRequests int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			atomic.AddInt32(&tokenRequests, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"tok-abc123","expires_in":3600}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+
+		req, err := http.NewRequest(r.Method, r.URL.String(), r.Body)
+		if err != nil {
+			t.Fatalf("failed to build proxied request: %v", err)
+		}
+		req.Header = r.Header
+
+		res, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("failed to forward proxied request: %v", err)
+		}
+		defer res.Body.Close()
+
+		for k, v := range res.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(res.StatusCode)
+		io.Copy(w, res.Body)
+	}))
+	defer proxy.Close()
+
+	auth := OAuth2ClientCredentials(target.URL+"/token", "client-id", "client-secret")
+
+	res, err := New(http.MethodGet, target.URL).Auth(auth).ProxyURL(proxy.URL).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if got := atomic.LoadInt32(&tokenRequests); got != 1 {
+		t.Errorf("expected the token to be fetched once, got %d fetches", got)
+	}
+	if got := atomic.LoadInt32(&proxied); got < 2 {
+		t.Errorf("expected both the token fetch and the request itself to go through the proxy, got %d proxied calls", got)
+	}
+}
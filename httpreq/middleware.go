@@ -0,0 +1,64 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import "net/http"
+
+// DefaultBeforeRequest hooks are run, in order, before the per-request
+// OnBeforeRequest hooks of every Request built by New. Use this to install
+// cross-cutting concerns (tracing, metrics, request signing) globally
+// instead of registering them on every Request.
+var DefaultBeforeRequest []func(*Request, *http.Request) error
+
+// DefaultAfterResponse hooks are run, in order, before the per-request
+// OnAfterResponse hooks of every Request built by New.
+var DefaultAfterResponse []func(*Request, *http.Response) error
+
+// OnBeforeRequest registers a hook invoked, in registration order, right
+// before the request is handed to the http.Client. Returning an error aborts
+// the dispatch.
+func (r *Request) OnBeforeRequest(fn func(*Request, *http.Request) error) *Request {
+	r.beforeRequestHooks = append(r.beforeRequestHooks, fn)
+	return r
+}
+
+// OnAfterResponse registers a hook invoked, in registration order, right
+// after a response is successfully received. Returning an error aborts the
+// dispatch.
+func (r *Request) OnAfterResponse(fn func(*Request, *http.Response) error) *Request {
+	r.afterResponseHooks = append(r.afterResponseHooks, fn)
+	return r
+}
+
+// runBeforeRequestHooks runs the global DefaultBeforeRequest hooks followed
+// by this Request's own OnBeforeRequest hooks, in registration order
+func (r *Request) runBeforeRequestHooks(req *http.Request) error {
+	for _, fn := range DefaultBeforeRequest {
+		if err := fn(r, req); err != nil {
+			return err
+		}
+	}
+	for _, fn := range r.beforeRequestHooks {
+		if err := fn(r, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfterResponseHooks runs the global DefaultAfterResponse hooks followed
+// by this Request's own OnAfterResponse hooks, in registration order
+func (r *Request) runAfterResponseHooks(res *http.Response) error {
+	for _, fn := range DefaultAfterResponse {
+		if err := fn(r, res); err != nil {
+			return err
+		}
+	}
+	for _, fn := range r.afterResponseHooks {
+		if err := fn(r, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
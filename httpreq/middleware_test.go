@@ -0,0 +1,81 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestMiddlewareHooksRunInOrder(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	var order []string
+	DefaultBeforeRequest = []func(*Request, *http.Request) error{
+		func(r *Request, req *http.Request) error {
+			order = append(order, "default-before")
+			return nil
+		},
+	}
+	DefaultAfterResponse = []func(*Request, *http.Response) error{
+		func(r *Request, res *http.Response) error {
+			order = append(order, "default-after")
+			return nil
+		},
+	}
+	defer func() {
+		DefaultBeforeRequest = nil
+		DefaultAfterResponse = nil
+	}()
+
+	res, err := New(http.MethodGet, serv.URL).
+		OnBeforeRequest(func(r *Request, req *http.Request) error {
+			order = append(order, "before")
+			req.Header.Set("X-Signed", "true")
+			return nil
+		}).
+		OnAfterResponse(func(r *Request, res *http.Response) error {
+			order = append(order, "after")
+			return nil
+		}).
+		Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	want := []string{"default-before", "before", "default-after", "after"}
+	if len(order) != len(want) {
+		t.Fatalf("expected hook order %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("expected hook order %v, got %v", want, order)
+			break
+		}
+	}
+}
+
+func TestRequestOnBeforeRequestErrorAbortsDispatch(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("server should not have been called")
+	}))
+	defer serv.Close()
+
+	errSigning := errors.New("signing failed")
+	_, err := New(http.MethodGet, serv.URL).
+		OnBeforeRequest(func(r *Request, req *http.Request) error {
+			return errSigning
+		}).
+		Dispatch()
+
+	if !errors.Is(err, errSigning) {
+		t.Errorf("expected error %v, got %v", errSigning, err)
+	}
+}
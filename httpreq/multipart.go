@@ -0,0 +1,131 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+)
+
+// formField is a plain key/value pair written as a multipart form field
+type formField struct {
+	key   string
+	value string
+}
+
+// formFilePart is a file part written as a multipart form file. reader is
+// used unless path is set, in which case the file at path is opened and
+// streamed lazily when the body is built. reader's contents are buffered
+// into memory the first time the part is written, so the same formFilePart
+// can be safely reused across retry attempts.
+type formFilePart struct {
+	field    string
+	filename string
+	reader   io.Reader
+	path     string
+	buffered []byte
+}
+
+// FormField adds a plain key/value field to a multipart/form-data request.
+// Setting any FormField or FormFile switches the request body to multipart,
+// taking precedence over Body/BodyStruct/BodyValues.
+func (r *Request) FormField(key, value string) *Request {
+	r.formFields = append(r.formFields, formField{key: key, value: value})
+	return r
+}
+
+// FormFile adds a file part to a multipart/form-data request. reader's
+// contents are buffered into memory the first time the request is
+// dispatched, so the part can be safely re-sent if the request is retried;
+// for large files that need to stay off the heap, prefer FormFileFromPath,
+// which re-opens and streams the file from disk on every attempt instead.
+func (r *Request) FormFile(field, filename string, reader io.Reader) *Request {
+	r.formFiles = append(r.formFiles, formFilePart{field: field, filename: filename, reader: reader})
+	return r
+}
+
+// FormFileFromPath adds a file part read from the file at path, opened and
+// streamed lazily when the body is built.
+func (r *Request) FormFileFromPath(field, path string) *Request {
+	r.formFiles = append(r.formFiles, formFilePart{field: field, filename: filepath.Base(path), path: path})
+	return r
+}
+
+// hasMultipartForm reports whether FormField/FormFile/FormFileFromPath was
+// used on this request
+func (r *Request) hasMultipartForm() bool {
+	return len(r.formFields) > 0 || len(r.formFiles) > 0
+}
+
+// multipartBodyFn returns a body factory producing a multipart/form-data
+// body. The body is backed by an io.Pipe so fields and file parts are
+// interleaved and streamed as the http client reads them. FormFileFromPath
+// parts are re-opened from disk on every call; FormFile(io.Reader) parts are
+// buffered into memory the first time they're needed so that the factory
+// can be safely invoked more than once (e.g. across Retry attempts).
+func (r *Request) multipartBodyFn() func() (io.Reader, string, error) {
+	return func() (io.Reader, string, error) {
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		contentType := mw.FormDataContentType()
+
+		go func() {
+			defer pw.Close()
+
+			for _, field := range r.formFields {
+				if err := mw.WriteField(field.key, field.value); err != nil {
+					pw.CloseWithError(fmt.Errorf("failed to write form field %q: %w", field.key, err))
+					return
+				}
+			}
+
+			for i := range r.formFiles {
+				file := &r.formFiles[i]
+				part, err := mw.CreateFormFile(file.field, file.filename)
+				if err != nil {
+					pw.CloseWithError(fmt.Errorf("failed to create form file %q: %w", file.field, err))
+					return
+				}
+
+				if file.path != "" {
+					f, err := os.Open(file.path)
+					if err != nil {
+						pw.CloseWithError(fmt.Errorf("failed to open %q: %w", file.path, err))
+						return
+					}
+					_, copyErr := io.Copy(part, f)
+					f.Close()
+					if copyErr != nil {
+						pw.CloseWithError(fmt.Errorf("failed to stream form file %q: %w", file.field, copyErr))
+						return
+					}
+					continue
+				}
+
+				if file.buffered == nil {
+					data, err := ioutil.ReadAll(file.reader)
+					if err != nil {
+						pw.CloseWithError(fmt.Errorf("failed to read form file %q: %w", file.field, err))
+						return
+					}
+					file.buffered = data
+				}
+				if _, err := part.Write(file.buffered); err != nil {
+					pw.CloseWithError(fmt.Errorf("failed to stream form file %q: %w", file.field, err))
+					return
+				}
+			}
+
+			if err := mw.Close(); err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to finalize multipart body: %w", err))
+			}
+		}()
+
+		return pr, contentType, nil
+	}
+}
@@ -0,0 +1,147 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestMultipartFormUpload(t *testing.T) {
+	var gotFieldValue, gotFileName, gotFileContents string
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		gotFieldValue = r.FormValue("name")
+
+		file, header, err := r.FormFile("upload")
+		if err != nil {
+			t.Fatalf("failed to read form file: %v", err)
+		}
+		defer file.Close()
+		gotFileName = header.Filename
+
+		contents, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatalf("failed to read file contents: %v", err)
+		}
+		gotFileContents = string(contents)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodPost, serv.URL).
+		FormField("name", "gamma").
+		FormFile("upload", "greeting.txt", strings.NewReader("hello multipart")).
+		Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if gotFieldValue != "gamma" {
+		t.Errorf("expected form field %q, got %q", "gamma", gotFieldValue)
+	}
+	if gotFileName != "greeting.txt" {
+		t.Errorf("expected filename %q, got %q", "greeting.txt", gotFileName)
+	}
+	if gotFileContents != "hello multipart" {
+		t.Errorf("expected file contents %q, got %q", "hello multipart", gotFileContents)
+	}
+}
+
+func TestRequestFormFileFromPath(t *testing.T) {
+	tmp, err := ioutil.TempFile("", "httpreq-multipart-*.txt")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString("from disk"); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	tmp.Close()
+
+	var gotFileContents string
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		file, _, err := r.FormFile("upload")
+		if err != nil {
+			t.Fatalf("failed to read form file: %v", err)
+		}
+		defer file.Close()
+
+		contents, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatalf("failed to read file contents: %v", err)
+		}
+		gotFileContents = string(contents)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodPost, serv.URL).FormFileFromPath("upload", tmp.Name()).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if gotFileContents != "from disk" {
+		t.Errorf("expected file contents %q, got %q", "from disk", gotFileContents)
+	}
+}
+
+func TestRequestFormFileSurvivesRetry(t *testing.T) {
+	var attempts int32
+	var gotFileContents string
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart form: %v", err)
+		}
+		file, _, err := r.FormFile("upload")
+		if err != nil {
+			t.Fatalf("failed to read form file: %v", err)
+		}
+		defer file.Close()
+
+		contents, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatalf("failed to read file contents: %v", err)
+		}
+		gotFileContents = string(contents)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodPost, serv.URL).
+		FormFile("upload", "greeting.txt", strings.NewReader("hello multipart")).
+		Retry(2, time.Millisecond).
+		Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if gotFileContents != "hello multipart" {
+		t.Errorf("expected the retried attempt to still carry the file contents, got %q", gotFileContents)
+	}
+}
@@ -5,6 +5,7 @@ package httpreq
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -12,10 +13,15 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -28,6 +34,11 @@ const (
 	pathParamIndicator    = ":"
 	urlSeparator          = "/"
 	urlPathParamSeparator = "/:"
+
+	// maxRetryBackoffShift caps the exponent used to compute exponential
+	// backoff so a large maxAttempts (e.g. Retry(100, ...)) can't shift the
+	// attempt count past 63 bits and wrap the resulting duration negative.
+	maxRetryBackoffShift = 30
 )
 
 var (
@@ -39,6 +50,11 @@ var (
 	errorResponseStructIsNil    = errors.New("response struct is nil")
 	errNoPathParamValueSet      = errors.New("no path param value passed")
 	errPathParamPassedIncorrect = errors.New("not enough path parameter values passed")
+
+	// defaultRetryStatusCodes are the response codes considered transient
+	// and therefore retryable when Retry is enabled and RetryOn hasn't
+	// been called to override them
+	defaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
 )
 
 // Request is client that constructs the http request
@@ -58,12 +74,27 @@ type Request struct {
 	contentType string
 	verbose     bool
 
-	username string
-	password string
+	auth Authenticator
+
+	body         io.Reader
+	bodyBuffered []byte
+	bodyStruct   interface{}
+	bodyValues   url.Values
+	formFields   []formField
+	formFiles    []formFilePart
+
+	retryMaxAttempts int
+	retryBaseDelay   time.Duration
+	retryCodes       []int
+	retryCondition   func(*http.Response, error) bool
 
-	body       io.Reader
-	bodyStruct interface{}
-	bodyValues url.Values
+	beforeRequestHooks []func(*Request, *http.Request) error
+	afterResponseHooks []func(*Request, *http.Response) error
+
+	ctx          context.Context
+	traceEnabled bool
+	tracer       trace.Tracer
+	traceInfo    *TraceInfo
 }
 
 // New constructs a httpreq.Request with passed method and url
@@ -139,6 +170,30 @@ func (r *Request) Timeout(timeout time.Duration) *Request {
 	return r
 }
 
+// WithContext associates ctx with the request, propagated via
+// http.NewRequestWithContext so cancellation/deadlines set by the caller are
+// honored. Without it, Dispatch falls back to context.Background().
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// Trace opts the request into capturing DNS, connect, TLS handshake and
+// time-to-first-byte timings via an httptrace.ClientTrace, made available
+// on the dispatched Response's TraceInfo field.
+func (r *Request) Trace(trace bool) *Request {
+	r.traceEnabled = trace
+	return r
+}
+
+// Tracer sets an OpenTelemetry trace.Tracer used to emit a span around the
+// dispatched request. The span context is propagated into the outgoing
+// request headers via otel.GetTextMapPropagator().
+func (r *Request) Tracer(tracer trace.Tracer) *Request {
+	r.tracer = tracer
+	return r
+}
+
 // UnescapeQueryParams perform the unescaping the query params before
 // dispatching the request
 func (r *Request) UnescapeQueryParams(unescape bool) *Request {
@@ -156,13 +211,41 @@ func (r *Request) Verbose(verbose bool) *Request {
 
 // BasicAuth set the base64 auth token in header
 func (r *Request) BasicAuth(username, password string) *Request {
-	r.username = username
-	r.password = password
+	return r.Auth(&basicAuthenticator{username: username, password: password})
+}
+
+// Retry enables automatic retries for this request, up to maxAttempts total
+// attempts, with exponential backoff (baseDelay * 2^attempt) plus random
+// jitter in [0, baseDelay) between attempts. By default a response is
+// retried when its status is 429, 502, 503 or 504, or when the transport
+// itself returns a network/timeout error; use RetryOn or RetryIf to
+// customize what is considered retryable. A maxAttempts of 1 or less
+// disables retries.
+func (r *Request) Retry(maxAttempts int, baseDelay time.Duration) *Request {
+	r.retryMaxAttempts = maxAttempts
+	r.retryBaseDelay = baseDelay
+	return r
+}
+
+// RetryOn overrides the set of response status codes that are considered
+// retryable. It has no effect unless Retry has also been called.
+func (r *Request) RetryOn(codes ...int) *Request {
+	r.retryCodes = codes
+	return r
+}
+
+// RetryIf overrides the retry decision entirely: fn is called with the
+// response (nil on transport error) and the transport error (nil on a
+// completed response) and should return true if the request should be
+// retried. It has no effect unless Retry has also been called.
+func (r *Request) RetryIf(fn func(*http.Response, error) bool) *Request {
+	r.retryCondition = fn
 	return r
 }
 
 // DispatchScan performs sending the actual http request
-// and scan the response (unmarshall to an struct)
+// and scan the response (unmarshall to an struct), branching on the
+// response Content-Type (see Response.Into)
 func (r *Request) DispatchScan(response interface{}) error {
 	if response == nil {
 		return errorResponseStructIsNil
@@ -170,21 +253,30 @@ func (r *Request) DispatchScan(response interface{}) error {
 
 	res, err := r.Dispatch()
 	if err != nil {
-		if res != nil {
-			res.Body.Close()
-		}
 		return err
 	}
 	defer res.Body.Close()
 
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+	if err := res.Into(response); err != nil {
 		return fmt.Errorf("failed to decode %d response: %w", res.StatusCode, err)
 	}
 	return nil
 }
 
-// Dispatch send the http request to the passed URL
-func (r *Request) Dispatch() (*http.Response, error) {
+// Dispatch send the http request to the passed URL, returning a Response
+// wrapping the result with decoding helpers. Use DispatchResponse if you
+// need the raw *http.Response instead.
+func (r *Request) Dispatch() (*Response, error) {
+	res, err := r.DispatchResponse()
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Response: res, TraceInfo: r.traceInfo}, nil
+}
+
+// DispatchResponse send the http request to the passed URL and returns the
+// raw *http.Response
+func (r *Request) DispatchResponse() (*http.Response, error) {
 	// validate the metho type
 	if err := r.validateMethod(); err != nil {
 		return nil, err
@@ -195,32 +287,211 @@ func (r *Request) Dispatch() (*http.Response, error) {
 		return nil, err
 	}
 
-	// prepare the body
-	body := r.body
-	contentType := r.contentType
-	if body == nil {
-		if r.bodyStruct != nil {
+	// buffer the body ahead of time so every attempt can rewind/re-marshal it
+	bodyFn, err := r.bodyFactory()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.prepareClient(); err != nil {
+		return nil, err
+	}
+
+	if aware, ok := r.auth.(clientAwareAuthenticator); ok {
+		aware.useClient(r.httpcli)
+	}
+
+	baseCtx := r.ctx
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+	ctx, span := r.startSpan(baseCtx)
+
+	// Digest auth needs a server-issued nonce before it can sign anything,
+	// so run a pre-flight challenge request the first time it's used
+	if digest, ok := r.auth.(*digestAuthenticator); ok && digest.needsChallenge() {
+		if err := r.performDigestChallenge(ctx, digest); err != nil {
+			endSpan(span, nil, err)
+			return nil, err
+		}
+	}
+
+	attempts := r.retryMaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var res *http.Response
+	for attempt := 0; attempt < attempts; attempt++ {
+		attemptCtx := ctx
+		var timings *traceTimings
+		if r.traceEnabled {
+			timings = &traceTimings{}
+			attemptCtx = withClientTrace(ctx, timings)
+		}
+
+		body, contentType, err := bodyFn()
+		if err != nil {
+			endSpan(span, nil, err)
+			return nil, err
+		}
+
+		req, err := r.buildHTTPRequest(attemptCtx, body, contentType)
+		if err != nil {
+			endSpan(span, nil, err)
+			return nil, err
+		}
+
+		if r.tracer != nil {
+			injectTraceContext(ctx, req)
+		}
+
+		if err := r.runBeforeRequestHooks(req); err != nil {
+			err = fmt.Errorf("before-request hook failed: %w", err)
+			endSpan(span, nil, err)
+			return nil, err
+		}
+
+		if r.verbose {
+			log.Println("dispatching request to ", req.URL.String())
+		}
+
+		res, err = r.httpcli.Do(req)
+		if timings != nil {
+			timings.end = time.Now()
+		}
+		if err != nil {
+			if res != nil {
+				res.Body.Close()
+			}
+			if attempt < attempts-1 && r.shouldRetry(nil, err) {
+				time.Sleep(r.retryDelay(attempt, nil))
+				continue
+			}
+			err = fmt.Errorf("dispatching request failed :%w", err)
+			endSpan(span, nil, err)
+			return nil, err
+		}
+
+		if timings != nil {
+			r.traceInfo = timings.info()
+		}
+
+		if err := r.runAfterResponseHooks(res); err != nil {
+			res.Body.Close()
+			err = fmt.Errorf("after-response hook failed: %w", err)
+			endSpan(span, nil, err)
+			return nil, err
+		}
+
+		// log the response body to console if verbose
+		// this would be helpful while troubleshooting
+		if r.verbose {
+			resbody, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				err = fmt.Errorf("error reading body: %v", err)
+				endSpan(span, nil, err)
+				return nil, err
+			}
+			log.Printf("dispatch response: [%d][%s][%s]", res.StatusCode, res.Status, string(resbody))
+
+			// set a new body
+			res.Body = ioutil.NopCloser(bytes.NewBuffer(resbody))
+		}
+
+		if attempt < attempts-1 && r.shouldRetry(res, nil) {
+			delay := r.retryDelay(attempt, res)
+			res.Body.Close()
+			time.Sleep(delay)
+			continue
+		}
+		break
+	}
+	endSpan(span, res, nil)
+	return res, nil
+}
+
+// bodyFactory returns a function that produces the request body and its
+// content type on demand. It is called once per attempt so that
+// BodyStruct/BodyValues are re-marshalled and Body's io.Reader is rewound
+// from a buffered copy, making every attempt retry-safe.
+func (r *Request) bodyFactory() (func() (io.Reader, string, error), error) {
+	if r.hasMultipartForm() {
+		return r.multipartBodyFn(), nil
+	}
+
+	switch {
+	case r.body != nil:
+		if r.bodyBuffered == nil {
+			data, err := ioutil.ReadAll(r.body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read request body: %w", err)
+			}
+			r.bodyBuffered = data
+		}
+		contentType := r.contentType
+		return func() (io.Reader, string, error) {
+			return bytes.NewReader(r.bodyBuffered), contentType, nil
+		}, nil
+	case r.bodyStruct != nil:
+		return func() (io.Reader, string, error) {
 			bits, err := json.Marshal(r.bodyStruct)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshall json request: %w", err)
+				return nil, "", fmt.Errorf("failed to marshall json request: %w", err)
 			}
-			body = bytes.NewBuffer(bits)
-			contentType = ContentTypeJSON
-		} else if r.bodyValues != nil {
-			body = bytes.NewBufferString(r.bodyValues.Encode())
-			contentType = ContentTypeURLENCODED
+			return bytes.NewBuffer(bits), ContentTypeJSON, nil
+		}, nil
+	case r.bodyValues != nil:
+		return func() (io.Reader, string, error) {
+			return bytes.NewBufferString(r.bodyValues.Encode()), ContentTypeURLENCODED, nil
+		}, nil
+	}
+	contentType := r.contentType
+	return func() (io.Reader, string, error) {
+		return nil, contentType, nil
+	}, nil
+}
+
+// performDigestChallenge issues a throwaway, bodyless request to obtain the
+// WWW-Authenticate: Digest challenge needed to sign the real attempts, per
+// RFC 7616's challenge/response flow. It deliberately doesn't send the
+// request body: a 401 challenge doesn't need one, so the body is only
+// produced for the real, signed attempts.
+func (r *Request) performDigestChallenge(ctx context.Context, digest *digestAuthenticator) error {
+	req, err := r.buildHTTPRequest(ctx, nil, "")
+	if err != nil {
+		return err
+	}
+
+	res, err := r.httpcli.Do(req)
+	if err != nil {
+		if res != nil {
+			res.Body.Close()
 		}
+		return fmt.Errorf("digest auth challenge request failed: %w", err)
 	}
+	res.Body.Close()
 
-	// create request
-	req, err := http.NewRequest(r.method, r.url, body)
+	if res.StatusCode == http.StatusUnauthorized {
+		digest.handleChallenge(res)
+	}
+	return nil
+}
+
+// buildHTTPRequest assembles a fresh *http.Request from the builder state,
+// applying auth, headers, content type and query params. It is called once
+// per dispatch attempt.
+func (r *Request) buildHTTPRequest(ctx context.Context, body io.Reader, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request :%w", err)
 	}
 
-	// Sets the basic auth header
-	if r.username != "" || r.password != "" {
-		req.Header.Add("Authorization", "Basic "+basicAuth(r.username, r.password))
+	// Sets the authentication header, if any
+	if r.auth != nil {
+		if err := r.auth.Apply(req); err != nil {
+			return nil, fmt.Errorf("failed to apply authentication: %w", err)
+		}
 	}
 
 	// Sets the content type
@@ -253,11 +524,18 @@ func (r *Request) Dispatch() (*http.Response, error) {
 		req.URL.RawQuery = params
 	}
 
+	return req, nil
+}
+
+// prepareClient resolves the transport/proxy and builds the *http.Client
+// that will be used to dispatch every attempt, unless one was already
+// supplied via HTTPClient.
+func (r *Request) prepareClient() error {
 	r.transport = http.DefaultTransport
 	if r.httpcli == nil && r.proxyURL != "" {
 		proxy, err := url.Parse(r.proxyURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse proxy url %w", err)
+			return fmt.Errorf("failed to parse proxy url %w", err)
 		}
 		r.transport = &http.Transport{
 			Proxy: http.ProxyURL(proxy),
@@ -276,33 +554,86 @@ func (r *Request) Dispatch() (*http.Response, error) {
 		}
 		r.httpcli = httpcli
 	}
+	return nil
+}
 
-	if r.verbose {
-		log.Println("dispatching request to ", req.URL.String())
+// shouldRetry decides whether the attempt that produced res/err should be
+// retried, consulting RetryIf when set and otherwise falling back to the
+// default/overridden retry status codes plus any transport error.
+func (r *Request) shouldRetry(res *http.Response, err error) bool {
+	if r.retryCondition != nil {
+		return r.retryCondition(res, err)
 	}
 
-	// perform the actual request
-	res, err := r.httpcli.Do(req)
 	if err != nil {
-		if res != nil {
-			res.Body.Close()
+		return true
+	}
+
+	if res == nil {
+		return false
+	}
+
+	codes := r.retryCodes
+	if len(codes) == 0 {
+		codes = defaultRetryStatusCodes
+	}
+
+	for _, code := range codes {
+		if res.StatusCode == code {
+			return true
 		}
-		return nil, fmt.Errorf("dispatching request failed :%w", err)
 	}
+	return false
+}
 
-	// log the response body to console if verbose
-	// this would be helpful while troubleshooting
-	if r.verbose {
-		resbody, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("error reading body: %v", err)
+// retryDelay computes how long to wait before the next attempt, honoring a
+// Retry-After response header when present and otherwise falling back to
+// exponential backoff with jitter.
+func (r *Request) retryDelay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if delay, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	shift := attempt
+	if shift > maxRetryBackoffShift {
+		shift = maxRetryBackoffShift
+	}
+	factor := time.Duration(int64(1) << uint(shift))
+	backoff := r.retryBaseDelay * factor
+	if r.retryBaseDelay > math.MaxInt64/factor {
+		backoff = math.MaxInt64 - r.retryBaseDelay
+	}
+	var jitter time.Duration
+	if r.retryBaseDelay > 0 {
+		jitter = time.Duration(rand.Int63n(int64(r.retryBaseDelay)))
+	}
+	return backoff + jitter
+}
+
+// parseRetryAfter parses a Retry-After header value in either its
+// delta-seconds or HTTP-date form, as described in RFC 7231 section 7.1.3.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			secs = 0
 		}
-		log.Printf("dispatch response: [%d][%s][%s]", res.StatusCode, res.Status, string(resbody))
+		return time.Duration(secs) * time.Second, true
+	}
 
-		// set a new body
-		res.Body = ioutil.NopCloser(bytes.NewBuffer(resbody))
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay, true
+		}
+		return 0, true
 	}
-	return res, err
+
+	return 0, false
 }
 
 // validatePathParams checks whether the passed path params count
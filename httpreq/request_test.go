@@ -11,7 +11,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestHttpRequestDispatch(t *testing.T) {
@@ -320,7 +322,7 @@ func TestHttpRequestDispatch(t *testing.T) {
 			var res *http.Response
 			var err error
 			if !test.scanResult {
-				res, err = r.Dispatch()
+				res, err = r.DispatchResponse()
 			} else {
 				const expectedName = "foo"
 				var resp struct {
@@ -360,3 +362,115 @@ func testserver(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+func TestRequestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	var attempts int32
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Retry(5, time.Millisecond).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRequestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int32
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Retry(3, time.Millisecond).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, res.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRequestRetryHonorsRetryAfterHeader(t *testing.T) {
+	var attempts int32
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Retry(2, time.Second).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestRequestRetryOnCustomCodes(t *testing.T) {
+	var attempts int32
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Retry(2, time.Millisecond).RetryOn(http.StatusNotFound).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestRequestInvalidProxyURLReturnsError(t *testing.T) {
+	_, err := New(http.MethodGet, "http://example.com").ProxyURL("http://%zz").Dispatch()
+	if err == nil {
+		t.Fatal("expected an error for an invalid proxy url")
+	}
+}
+
+func TestRequestRetryDelayDoesNotOverflowOnLargeAttempts(t *testing.T) {
+	r := New(http.MethodGet, "http://example.com").Retry(100, time.Millisecond)
+
+	for _, attempt := range []int{62, 63, 100} {
+		if delay := r.retryDelay(attempt, nil); delay <= 0 {
+			t.Errorf("attempt %d: expected a positive backoff, got %v", attempt, delay)
+		}
+	}
+}
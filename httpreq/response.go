@@ -0,0 +1,113 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Response wraps the *http.Response returned by a dispatched Request with
+// convenience helpers for reading and decoding the body. The embedded
+// *http.Response's fields (StatusCode, Header, Body, ...) are promoted, so
+// callers can keep using res.StatusCode/res.Body as before.
+type Response struct {
+	*http.Response
+
+	// TraceInfo is populated when the originating Request had Trace(true)
+	// set, and is nil otherwise.
+	TraceInfo *TraceInfo
+
+	body []byte
+}
+
+// StatusOK reports whether the response status code is in the 2xx range
+func (res *Response) StatusOK() bool {
+	return res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices
+}
+
+// Bytes reads and returns the response body, buffering it so repeated calls
+// (or a call after String/JSON/XML/Into) don't re-read an already-closed
+// body.
+func (res *Response) Bytes() ([]byte, error) {
+	if res.body == nil {
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		res.body = data
+	}
+	return res.body, nil
+}
+
+// String reads and returns the response body as a string
+func (res *Response) String() (string, error) {
+	data, err := res.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// JSON reads the response body and unmarshals it as JSON into v
+func (res *Response) JSON(v interface{}) error {
+	data, err := res.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %d response as json: %w", res.StatusCode, err)
+	}
+	return nil
+}
+
+// XML reads the response body and unmarshals it as XML into v
+func (res *Response) XML(v interface{}) error {
+	data, err := res.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %d response as xml: %w", res.StatusCode, err)
+	}
+	return nil
+}
+
+// Into decodes the response body into v based on the response's
+// Content-Type header: any type containing "xml" (application/xml, text/xml,
+// application/atom+xml, ...) decodes as XML,
+// application/x-www-form-urlencoded parses into a *url.Values, and
+// everything else (including application/json, or no Content-Type at all)
+// decodes as JSON.
+func (res *Response) Into(v interface{}) error {
+	contentType := res.Header.Get("Content-Type")
+
+	switch {
+	case strings.Contains(contentType, "xml"):
+		return res.XML(v)
+	case strings.Contains(contentType, ContentTypeURLENCODED):
+		values, ok := v.(*url.Values)
+		if !ok {
+			return fmt.Errorf("into target must be *url.Values for a %s response", ContentTypeURLENCODED)
+		}
+		data, err := res.Bytes()
+		if err != nil {
+			return err
+		}
+		parsed, err := url.ParseQuery(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode %d response as form values: %w", res.StatusCode, err)
+		}
+		*values = parsed
+		return nil
+	default:
+		return res.JSON(v)
+	}
+}
@@ -0,0 +1,100 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestResponseJSON(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write([]byte(`{"Name":"foo"}`))
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	if !res.StatusOK() {
+		t.Errorf("expected StatusOK to be true, got status %d", res.StatusCode)
+	}
+
+	var body struct{ Name string }
+	if err := res.JSON(&body); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if body.Name != "foo" {
+		t.Errorf("expected Name %q, got %q", "foo", body.Name)
+	}
+}
+
+func TestResponseXML(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<Greeting><Name>foo</Name></Greeting>`))
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	var body struct {
+		Name string `xml:"Name"`
+	}
+	if err := res.Into(&body); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if body.Name != "foo" {
+		t.Errorf("expected Name %q, got %q", "foo", body.Name)
+	}
+}
+
+func TestResponseFormEncoded(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeURLENCODED)
+		w.Write([]byte(`name=foo&type=alpha`))
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	var values url.Values
+	if err := res.Into(&values); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if got := values.Get("name"); got != "foo" {
+		t.Errorf("expected name %q, got %q", "foo", got)
+	}
+}
+
+func TestResponseString(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plain text"))
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	str, err := res.String()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	if str != "plain text" {
+		t.Errorf("expected %q, got %q", "plain text", str)
+	}
+}
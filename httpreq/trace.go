@@ -0,0 +1,144 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceInfo holds the per-attempt timings captured when Trace(true) is set
+// on a Request, exposed on the dispatched Response.
+type TraceInfo struct {
+	DNSLookup    time.Duration
+	ConnTime     time.Duration
+	TLSHandshake time.Duration
+	ServerTime   time.Duration
+	ResponseTime time.Duration
+	TotalTime    time.Duration
+	IsConnReused bool
+	RemoteAddr   string
+}
+
+// traceTimings accumulates the raw timestamps reported by an
+// httptrace.ClientTrace over the course of a single dispatch attempt
+type traceTimings struct {
+	start                time.Time
+	dnsStart             time.Time
+	dnsDone              time.Time
+	connectStart         time.Time
+	connectDone          time.Time
+	tlsStart             time.Time
+	tlsDone              time.Time
+	gotFirstResponseByte time.Time
+	end                  time.Time
+	reused               bool
+	remoteAddr           string
+}
+
+// withClientTrace attaches an httptrace.ClientTrace to ctx that records its
+// timestamps into timings
+func withClientTrace(ctx context.Context, timings *traceTimings) context.Context {
+	timings.start = time.Now()
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			timings.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			timings.dnsDone = time.Now()
+		},
+		ConnectStart: func(string, string) {
+			timings.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			timings.connectDone = time.Now()
+		},
+		TLSHandshakeStart: func() {
+			timings.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timings.tlsDone = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			timings.gotFirstResponseByte = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			timings.reused = info.Reused
+			if info.Conn != nil {
+				timings.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+	})
+}
+
+// info derives a TraceInfo from the timestamps collected so far
+func (t *traceTimings) info() *TraceInfo {
+	end := t.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	info := &TraceInfo{
+		IsConnReused: t.reused,
+		RemoteAddr:   t.remoteAddr,
+		TotalTime:    end.Sub(t.start),
+	}
+	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
+		info.DNSLookup = t.dnsDone.Sub(t.dnsStart)
+	}
+	if !t.connectStart.IsZero() && !t.connectDone.IsZero() {
+		info.ConnTime = t.connectDone.Sub(t.connectStart)
+	}
+	if !t.tlsStart.IsZero() && !t.tlsDone.IsZero() {
+		info.TLSHandshake = t.tlsDone.Sub(t.tlsStart)
+	}
+	if !t.gotFirstResponseByte.IsZero() {
+		info.ServerTime = t.gotFirstResponseByte.Sub(t.start)
+		info.ResponseTime = end.Sub(t.gotFirstResponseByte)
+	}
+	return info
+}
+
+// startSpan starts an OpenTelemetry span for the dispatch when a Tracer has
+// been configured, returning the (possibly unchanged) context and the span,
+// which is nil when tracing isn't enabled.
+func (r *Request) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	if r.tracer == nil {
+		return ctx, nil
+	}
+	return r.tracer.Start(ctx, r.method+" "+r.url, trace.WithAttributes(
+		attribute.String("http.method", r.method),
+		attribute.String("http.url", r.url),
+	))
+}
+
+// endSpan records the outcome of the dispatch on span and ends it. It is a
+// no-op when span is nil.
+func endSpan(span trace.Span, res *http.Response, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if res != nil {
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	}
+	span.End()
+}
+
+// injectTraceContext propagates the span context from ctx into req's
+// headers using the globally configured otel.TextMapPropagator
+func injectTraceContext(ctx context.Context, req *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
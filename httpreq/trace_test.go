@@ -0,0 +1,66 @@
+// Package httpreq ...
+// Copyright (c) 2020, Ghazni Nattarshah <ghazni.nattarshah@gmail.com>
+// See LICENSE for licensing information
+package httpreq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestRequestWithContextCancellation(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(http.MethodGet, serv.URL).WithContext(ctx).Dispatch()
+	if err == nil {
+		t.Fatal("expected an error dispatching with an already-canceled context")
+	}
+}
+
+func TestRequestTraceCapturesTimings(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	res, err := New(http.MethodGet, serv.URL).Trace(true).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.TraceInfo == nil {
+		t.Fatal("expected TraceInfo to be populated")
+	}
+	if res.TraceInfo.TotalTime <= 0 {
+		t.Errorf("expected a positive TotalTime, got %v", res.TraceInfo.TotalTime)
+	}
+}
+
+func TestRequestTracerSpansRequest(t *testing.T) {
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer serv.Close()
+
+	tracer := otel.Tracer("httpreq-test")
+	res, err := New(http.MethodGet, serv.URL).Tracer(tracer).Dispatch()
+	if err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}